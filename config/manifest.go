@@ -0,0 +1,159 @@
+// Copyright 2014 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestFile is the name of the provenance/integrity manifest written alongside the DROID and
+// container signature files during harvest.
+const manifestFile = "signatures.manifest.json"
+
+// ManifestEntry records the provenance of a single harvested signature file, so that air-gapped
+// and forensic users can prove their signature set matches what the source published.
+type ManifestEntry struct {
+	SHA256         string    `json:"sha256"`
+	SourceURL      string    `json:"sourceURL"`
+	HarvestedAt    time.Time `json:"harvestedAt"`
+	ReleaseVersion string    `json:"releaseVersion"`
+}
+
+// Manifest maps signature file basenames (e.g. "DROID_SignatureFile_V78.xml") to their
+// ManifestEntry.
+type Manifest map[string]ManifestEntry
+
+// loadManifest reads the manifest from dir, returning an empty Manifest if none exists yet.
+func loadManifest(dir string) (Manifest, error) {
+	m := make(Manifest)
+	f, err := os.Open(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SaveManifestEntry records (or updates) the provenance of the signature file base in dir's
+// manifest. dir should be the directory the signature file was actually saved to (siegfried.home,
+// unless SetDroid/SetContainer point at a location elsewhere), so that DroidVerified and
+// ContainerVerified - which look for the manifest alongside the file they're validating - find it.
+func SaveManifestEntry(dir, base, sourceURL, releaseVersion string, harvestedAt time.Time) error {
+	digest, err := sha256File(filepath.Join(dir, base))
+	if err != nil {
+		return err
+	}
+	m, err := loadManifest(dir)
+	if err != nil {
+		return err
+	}
+	m[base] = ManifestEntry{
+		SHA256:         digest,
+		SourceURL:      sourceURL,
+		HarvestedAt:    harvestedAt,
+		ReleaseVersion: releaseVersion,
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, manifestFile), data, 0644)
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verify checks that the file at path matches the digest recorded in the manifest (kept alongside
+// it, in filepath.Dir(path)) for base, returning an error if there's no manifest entry or the
+// digest doesn't match.
+func verify(path, base string) error {
+	m, err := loadManifest(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	entry, ok := m[base]
+	if !ok {
+		return fmt.Errorf("config: no manifest entry for %s; run roy harvest to record provenance", base)
+	}
+	digest, err := sha256File(path)
+	if err != nil {
+		return err
+	}
+	if digest != entry.SHA256 {
+		return fmt.Errorf("config: %s fails integrity check: manifest records sha256 %s, file has %s", base, entry.SHA256, digest)
+	}
+	return nil
+}
+
+// DroidVerified returns the location of the DROID signature file after validating it against
+// the sha256 digest recorded in signatures.manifest.json.
+func DroidVerified() (string, error) {
+	base := DroidBase()
+	if base == "" {
+		return "", fmt.Errorf("config: no DROID signature file found")
+	}
+	path := droidPath()
+	if err := verify(path, base); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ContainerVerified returns the location of the DROID container signature file after validating
+// it against the sha256 digest recorded in signatures.manifest.json.
+func ContainerVerified() (string, error) {
+	base := ContainerBase()
+	if base == "" {
+		return "", fmt.Errorf("config: no container signature file found")
+	}
+	path := containerPath()
+	if err := verify(path, base); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// SetRequireVerified makes the plain Droid() and Container() getters fail closed (returning "")
+// unless the signature file passes DroidVerified/ContainerVerified's integrity check.
+func SetRequireVerified() func() private {
+	return func() private {
+		pronom.requireVerified = true
+		return private{}
+	}
+}
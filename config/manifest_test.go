@@ -0,0 +1,83 @@
+// Copyright 2014 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const base = "DROID_SignatureFile_V999.xml"
+	path := filepath.Join(dir, base)
+	if err := ioutil.WriteFile(path, []byte("<droid/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verify(path, base); err == nil {
+		t.Error("verify with no manifest entry should fail")
+	}
+
+	if err := SaveManifestEntry(dir, base, "http://example.com/"+base, "99", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(path, base); err != nil {
+		t.Errorf("verify after recording a manifest entry should succeed, got %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("<droid changed/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(path, base); err == nil {
+		t.Error("verify should fail once the file no longer matches the recorded digest")
+	}
+}
+
+func TestDroidVerifiedCustomDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-manifest-droid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const base = "DROID_SignatureFile_V999.xml"
+	path := filepath.Join(dir, base)
+	if err := ioutil.WriteFile(path, []byte("<droid/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveManifestEntry(dir, base, "http://example.com/"+base, "999", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	old := pronom.droid
+	defer func() { pronom.droid = old }()
+	pronom.droid = path // SetDroid can be given a full path, not just a filename relative to HOME
+
+	if got := DroidBase(); got != base {
+		t.Errorf("DroidBase() = %q, want the bare basename %q", got, base)
+	}
+	if _, err := DroidVerified(); err != nil {
+		t.Errorf("DroidVerified() with a custom signature directory should find the manifest recorded alongside it, got %v", err)
+	}
+}
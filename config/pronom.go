@@ -39,6 +39,9 @@ var pronom = struct {
 	harvestURL       string
 	harvestTimeout   time.Duration
 	harvestTransport *http.Transport
+	harvestCache     string // directory for recording ETag/Last-Modified state of harvested reports
+	harvestRefresh   bool   // only re-fetch reports the server reports as changed
+	requireVerified  bool   // make Droid()/Container() fail closed unless the manifest digest matches
 	// archive puids
 	zip    string
 	tar    string
@@ -67,7 +70,20 @@ var pronom = struct {
 
 // DROID returns the location of the DROID signature file.
 // If not set, infers the latest file.
+// If SetRequireVerified has been set, fails closed (returns "") unless the file passes the
+// integrity check in DroidVerified.
 func Droid() string {
+	if pronom.requireVerified {
+		path, err := DroidVerified()
+		if err != nil {
+			return ""
+		}
+		return path
+	}
+	return droidPath()
+}
+
+func droidPath() string {
 	if pronom.droid == "" {
 		droid, err := latest("DROID_SignatureFile_V", ".xml")
 		if err != nil {
@@ -91,12 +107,25 @@ func DroidBase() string {
 		}
 		return droid
 	}
-	return pronom.droid
+	return filepath.Base(pronom.droid)
 }
 
 // Container returns the location of the DROID container signature file.
 // If not set, infers the latest file.
+// If SetRequireVerified has been set, fails closed (returns "") unless the file passes the
+// integrity check in ContainerVerified.
 func Container() string {
+	if pronom.requireVerified {
+		path, err := ContainerVerified()
+		if err != nil {
+			return ""
+		}
+		return path
+	}
+	return containerPath()
+}
+
+func containerPath() string {
 	if pronom.container == "" {
 		container, err := latest("container-signature-", ".xml")
 		if err != nil {
@@ -120,7 +149,7 @@ func ContainerBase() string {
 		}
 		return container
 	}
-	return pronom.container
+	return filepath.Base(pronom.container)
 }
 
 func latest(prefix, suffix string) (string, error) {
@@ -250,23 +279,67 @@ func ExtendC() []string {
 	return extensionPaths(pronom.extendc)
 }
 
-// HarvestOptions reports the PRONOM url, timeout and transport.
+// HarvestOptions reports the harvest url, timeout and transport. The url is taken from the first
+// active signature source that harvests over HTTP (see SignatureSource.HarvestURL), falling back
+// to the PRONOM default if none of the active sources supply one.
 func HarvestOptions() (string, time.Duration, *http.Transport) {
-	return pronom.harvestURL, pronom.harvestTimeout, pronom.harvestTransport
+	url := pronom.harvestURL
+	for _, s := range sources {
+		if u := s.HarvestURL(); u != "" {
+			url = u
+			break
+		}
+	}
+	return url, pronom.harvestTimeout, pronom.harvestTransport
 }
 
-// ZipPuid reports the puid for a zip archive.
+// HarvestCache returns the directory used to record ETag/Last-Modified state for harvested PRONOM reports.
+// If unset, defaults to the reports directory itself.
+func HarvestCache() string {
+	if pronom.harvestCache == "" {
+		return Reports()
+	}
+	if filepath.Dir(pronom.harvestCache) == "." {
+		return filepath.Join(siegfried.home, pronom.harvestCache)
+	}
+	return pronom.harvestCache
+}
+
+// HarvestRefresh reports whether harvesting should be limited to PUIDs the server reports as changed.
+func HarvestRefresh() bool {
+	return pronom.harvestRefresh
+}
+
+// ZipPuid reports the identifier the first active signature source uses for a zip archive, or ""
+// if none of them recognise zip.
 func ZipPuid() string {
-	return pronom.zip
+	for _, s := range sources {
+		if id := s.ArchiveID(Zip); id != "" {
+			return id
+		}
+	}
+	return ""
 }
 
-// TextPuid reports the puid for a text file.
+// TextPuid reports the identifier the first active signature source uses for a plain text file,
+// or "" if none of them recognise text.
 func TextPuid() string {
-	return pronom.text
+	for _, s := range sources {
+		if id := s.TextID(); id != "" {
+			return id
+		}
+	}
+	return ""
 }
 
-// IsArchive returns an Archive that corresponds to the provided puid (or none if no match).
+// IsArchive returns the Archive kind for puid/id p, asking each active signature source in turn
+// (see ArchiveKind), so that archive detection works regardless of which source p came from.
 func IsArchive(p string) Archive {
+	return ArchiveKind(p)
+}
+
+// archiveKind is PRONOM's own archive-puid lookup, consulted by pronomSource.ArchiveKind.
+func archiveKind(p string) Archive {
 	switch p {
 	case pronom.zip:
 		return Zip
@@ -282,6 +355,24 @@ func IsArchive(p string) Archive {
 	return None
 }
 
+// archiveID is PRONOM's own reverse lookup from Archive kind to puid, consulted by
+// pronomSource.ArchiveID.
+func archiveID(kind Archive) string {
+	switch kind {
+	case Zip:
+		return pronom.zip
+	case Gzip:
+		return pronom.gzip
+	case Tar:
+		return pronom.tar
+	case ARC:
+		return pronom.arc
+	case WARC:
+		return pronom.warc
+	}
+	return ""
+}
+
 // SETTERS
 
 // SetDroid sets the name and/or location of the DROID signature file.
@@ -377,3 +468,20 @@ func SetHarvestTimeout(d time.Duration) {
 func SetHarvestTransport(t *http.Transport) {
 	pronom.harvestTransport = t
 }
+
+// SetHarvestCache sets the directory used to record ETag/Last-Modified state for harvested PRONOM reports.
+func SetHarvestCache(dir string) {
+	pronom.harvestCache = dir
+}
+
+// SetHarvestRefresh limits harvesting to the PUIDs the server reports as changed, reusing cached reports
+// (based on a 304 Not Modified response) for everything else.
+func SetHarvestRefresh() {
+	pronom.harvestRefresh = true
+}
+
+// ClearHarvestRefresh turns harvest refresh back off, e.g. so a test that calls SetHarvestRefresh
+// can restore the package's default behaviour afterwards.
+func ClearHarvestRefresh() {
+	pronom.harvestRefresh = false
+}
@@ -0,0 +1,301 @@
+// Copyright 2014 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Report is a single harvested signature report, as fetched from a SignatureSource.
+type Report struct {
+	ID   string
+	Data []byte
+}
+
+// SignatureSource is implemented by each signature registry backend (PRONOM, a local
+// JSON catalogue, the Library of Congress FDD registry, ...) that roy can draw
+// signatures from. Identifiers are namespaced by Namespace() so that results built from
+// more than one source stay distinguishable downstream.
+type SignatureSource interface {
+	// Fetch retrieves the report for the given identifier.
+	Fetch(id string) (Report, error)
+	// List returns every identifier this source can supply.
+	List() ([]string, error)
+	// ArchiveKind reports whether id identifies a recognised archive format, or None.
+	ArchiveKind(id string) Archive
+	// ArchiveID reports the identifier this source uses for the given Archive kind, or "" if it
+	// doesn't recognise that kind.
+	ArchiveID(kind Archive) string
+	// TextID reports the identifier this source uses for a plain text file, or "" if none.
+	TextID() string
+	// HarvestURL reports the base URL this source harvests reports from over HTTP, or "" if it
+	// doesn't harvest (e.g. a local catalogue).
+	HarvestURL() string
+	// Namespace prefixes identifiers emitted by this source, e.g. "pronom", "loc", "local".
+	Namespace() string
+}
+
+// Namespace joins a source's namespace and an identifier into the form results carry, e.g.
+// "pronom:x-fmt/111".
+func Namespace(ns, id string) string {
+	return ns + ":" + id
+}
+
+// splitNamespace splits a namespaced identifier (as returned by List or Namespace) back into its
+// namespace and bare identifier.
+func splitNamespace(nsid string) (ns, id string, ok bool) {
+	i := strings.Index(nsid, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return nsid[:i], nsid[i+1:], true
+}
+
+// List returns every identifier across all active signature sources, namespaced (see Namespace)
+// so that identifiers from different sources stay distinguishable.
+func List() ([]string, error) {
+	var ids []string
+	for _, s := range sources {
+		raw, err := s.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range raw {
+			ids = append(ids, Namespace(s.Namespace(), id))
+		}
+	}
+	return ids, nil
+}
+
+// Fetch retrieves the report for a namespaced identifier (as returned by List), dispatching to
+// whichever active source owns that namespace.
+func Fetch(nsid string) (Report, error) {
+	ns, id, ok := splitNamespace(nsid)
+	if !ok {
+		return Report{}, fmt.Errorf("config: identifier %q is not namespaced, expected ns:id", nsid)
+	}
+	for _, s := range sources {
+		if s.Namespace() == ns {
+			return s.Fetch(id)
+		}
+	}
+	return Report{}, fmt.Errorf("config: no active signature source registered for namespace %q", ns)
+}
+
+// pronomSource is the default SignatureSource, serving reports already harvested into the
+// PRONOM reports directory (see Reports, SetReports and the roy harvest command).
+type pronomSource struct{}
+
+func (pronomSource) Fetch(id string) (Report, error) {
+	dir := Reports()
+	if dir == "" {
+		return Report{}, fmt.Errorf("config: no PRONOM reports directory configured")
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, strings.Replace(id, "/", "-", 1)+".xml"))
+	if err != nil {
+		return Report{}, err
+	}
+	return Report{ID: id, Data: data}, nil
+}
+
+func (pronomSource) List() ([]string, error) {
+	dir := Reports()
+	if dir == "" {
+		return nil, fmt.Errorf("config: no PRONOM reports directory configured")
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(files))
+	for _, f := range files {
+		nm := f.Name()
+		if !strings.HasSuffix(nm, ".xml") {
+			continue
+		}
+		// undo Fetch's "/" -> "-" mapping at the last "-", so x-fmt-111.xml lists as x-fmt/111
+		id := strings.TrimSuffix(nm, ".xml")
+		if i := strings.LastIndex(id, "-"); i >= 0 {
+			id = id[:i] + "/" + id[i+1:]
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (pronomSource) ArchiveKind(id string) Archive { return archiveKind(id) }
+func (pronomSource) ArchiveID(kind Archive) string { return archiveID(kind) }
+func (pronomSource) TextID() string                { return pronom.text }
+func (pronomSource) HarvestURL() string            { return pronom.harvestURL }
+func (pronomSource) Namespace() string             { return "pronom" }
+
+// localCatalogue is the on-disk shape of a local JSON signature catalogue, as read by
+// newLocalSource.
+type localCatalogue struct {
+	Archives map[string]string          `json:"archives"` // archive kind name (e.g. "zip") to identifier
+	Text     string                     `json:"text"`
+	Reports  map[string]json.RawMessage `json:"reports"`
+}
+
+// localSource is a SignatureSource backed by a single JSON signature catalogue file, for in-house
+// formats that aren't registered with PRONOM.
+type localSource struct {
+	path string
+	cat  localCatalogue
+}
+
+// newLocalSource loads a local signature catalogue from path. Only JSON catalogues are supported;
+// path must have a ".json" extension.
+func newLocalSource(path string) (*localSource, error) {
+	if ext := filepath.Ext(path); ext != ".json" {
+		return nil, fmt.Errorf("config: local signature catalogue %s: unsupported extension %q, only JSON catalogues (.json) are supported", path, ext)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cat localCatalogue
+	if err := json.Unmarshal(data, &cat); err != nil {
+		return nil, fmt.Errorf("config: invalid local signature catalogue %s: %v", path, err)
+	}
+	return &localSource{path: path, cat: cat}, nil
+}
+
+func (l *localSource) Fetch(id string) (Report, error) {
+	raw, ok := l.cat.Reports[id]
+	if !ok {
+		return Report{}, fmt.Errorf("config: no report for %s in local catalogue %s", id, l.path)
+	}
+	return Report{ID: id, Data: []byte(raw)}, nil
+}
+
+func (l *localSource) List() ([]string, error) {
+	ids := make([]string, 0, len(l.cat.Reports))
+	for id := range l.cat.Reports {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// archiveKindNames maps the archive kind names used in a local catalogue's "archives" object to
+// the corresponding Archive constant.
+var archiveKindNames = map[string]Archive{
+	"zip":  Zip,
+	"tar":  Tar,
+	"gzip": Gzip,
+	"arc":  ARC,
+	"warc": WARC,
+}
+
+func (l *localSource) ArchiveKind(id string) Archive {
+	for kind, archiveID := range l.cat.Archives {
+		if archiveID == id {
+			return archiveKindNames[kind]
+		}
+	}
+	return None
+}
+
+func (l *localSource) ArchiveID(kind Archive) string {
+	for name, k := range archiveKindNames {
+		if k == kind {
+			return l.cat.Archives[name]
+		}
+	}
+	return ""
+}
+
+func (l *localSource) TextID() string     { return l.cat.Text }
+func (l *localSource) HarvestURL() string { return "" }
+func (l *localSource) Namespace() string  { return "local" }
+
+// locSource is a stub SignatureSource for the Library of Congress FDD registry. Fetching and
+// listing aren't implemented yet, pending settling on an FDD report format to parse.
+type locSource struct{}
+
+func (locSource) Fetch(id string) (Report, error) {
+	return Report{}, fmt.Errorf("config: LOC FDD source not yet implemented (requested %s)", id)
+}
+
+func (locSource) List() ([]string, error) {
+	return nil, fmt.Errorf("config: LOC FDD source not yet implemented")
+}
+
+func (locSource) ArchiveKind(string) Archive { return None }
+func (locSource) ArchiveID(Archive) string   { return "" }
+func (locSource) TextID() string             { return "" }
+func (locSource) HarvestURL() string         { return "" }
+func (locSource) Namespace() string          { return "loc" }
+
+// sources is the set of SignatureSources that roy build will draw signatures from. Defaults to
+// PRONOM alone.
+var sources = []SignatureSource{pronomSource{}}
+
+// Sources returns the currently active signature sources, in the order they were registered.
+func Sources() []SignatureSource {
+	return sources
+}
+
+// ArchiveKind returns the Archive kind for id by asking each active source in turn, so that
+// archive handling works regardless of which source the puid/id came from.
+func ArchiveKind(id string) Archive {
+	for _, s := range sources {
+		if a := s.ArchiveKind(id); a != None {
+			return a
+		}
+	}
+	return None
+}
+
+// IsTextID reports whether id is the identifier any active source uses for plain text.
+func IsTextID(id string) bool {
+	for _, s := range sources {
+		if t := s.TextID(); t != "" && t == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSources sets the active signature sources, replacing the PRONOM-only default. Each spec is
+// one of "pronom", "loc", or "local:/path/to/catalog.json".
+func SetSources(specs []string) error {
+	newSources := make([]SignatureSource, 0, len(specs))
+	for _, spec := range specs {
+		switch {
+		case spec == "pronom":
+			newSources = append(newSources, pronomSource{})
+		case spec == "loc":
+			newSources = append(newSources, locSource{})
+		case strings.HasPrefix(spec, "local:"):
+			src, err := newLocalSource(strings.TrimPrefix(spec, "local:"))
+			if err != nil {
+				return err
+			}
+			newSources = append(newSources, src)
+		default:
+			return fmt.Errorf("config: unrecognised signature source %q", spec)
+		}
+	}
+	if len(newSources) == 0 {
+		return fmt.Errorf("config: no signature sources given")
+	}
+	sources = newSources
+	return nil
+}
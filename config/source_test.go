@@ -0,0 +1,115 @@
+// Copyright 2014 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func withSources(t *testing.T, specs []string) {
+	old := sources
+	t.Cleanup(func() { sources = old })
+	if err := SetSources(specs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeCatalogue(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "config-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	cat := localCatalogue{
+		Archives: map[string]string{"zip": "local-fmt/1"},
+		Text:     "local-fmt/2",
+		Reports:  map[string]json.RawMessage{"local-fmt/1": json.RawMessage(`"zip report"`)},
+	}
+	data, err := json.Marshal(cat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "catalog.json")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewLocalSourceRejectsNonJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "catalog.yaml")
+	if err := ioutil.WriteFile(path, []byte("archives:\n  zip: local-fmt/1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetSources([]string{"local:" + path}); err == nil {
+		t.Error("SetSources with a .yaml local catalogue should fail, only JSON catalogues are supported")
+	}
+}
+
+func TestArchiveAndTextDispatch(t *testing.T) {
+	withSources(t, []string{"local:" + writeCatalogue(t), "pronom"})
+
+	if got := ArchiveKind("local-fmt/1"); got != Zip {
+		t.Errorf("ArchiveKind(local-fmt/1) = %v, want Zip", got)
+	}
+	if got := IsArchive(pronom.tar); got != Tar {
+		t.Errorf("IsArchive(pronom tar puid) = %v, want Tar (pronomSource should still answer)", got)
+	}
+	if got := ZipPuid(); got != "local-fmt/1" {
+		t.Errorf("ZipPuid() = %q, want the first active source's zip id local-fmt/1", got)
+	}
+	if !IsTextID("local-fmt/2") {
+		t.Error("IsTextID(local-fmt/2) = false, want true")
+	}
+	if got := TextPuid(); got != "local-fmt/2" {
+		t.Errorf("TextPuid() = %q, want local-fmt/2", got)
+	}
+}
+
+func TestListAndFetchNamespaced(t *testing.T) {
+	withSources(t, []string{"local:" + writeCatalogue(t)})
+
+	ids, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "local:local-fmt/1" {
+		t.Fatalf("List() = %v, want [local:local-fmt/1]", ids)
+	}
+	report, err := Fetch(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ID != "local-fmt/1" || string(report.Data) != `"zip report"` {
+		t.Errorf("Fetch(%s) = %+v, unexpected report", ids[0], report)
+	}
+	if _, err := Fetch("noSuchNamespace:x"); err == nil {
+		t.Error("Fetch with an unregistered namespace should fail")
+	}
+	if _, err := Fetch("not-namespaced"); err == nil {
+		t.Error("Fetch with a non-namespaced identifier should fail")
+	}
+}
@@ -16,6 +16,7 @@ package bytematcher
 
 import (
 	//"fmt"
+	"context"
 	"io"
 
 	"github.com/richardlehane/match/wac"
@@ -23,8 +24,39 @@ import (
 	"github.com/richardlehane/siegfried/pkg/core/siegreader"
 )
 
+// quitChan returns a chan struct{} that closes when ctx is done, for handing to APIs
+// (siegreader, wac) that haven't yet migrated off the chan struct{} cancellation style.
+func quitChan(ctx context.Context) chan struct{} {
+	quit := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(quit)
+	}()
+	return quit
+}
+
+// identifyQuit is a deprecated shim for callers still using the chan struct{} cancellation
+// style. It will be removed once all callers migrate to identify's context.Context parameter.
+func (b *Matcher) identifyQuit(buf *siegreader.Buffer, quit chan struct{}, r chan core.Result) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	b.identify(ctx, buf, r)
+}
+
 // Identify function - brings a new matcher into existence
-func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan core.Result) {
+func (b *Matcher) identify(ctx context.Context, buf *siegreader.Buffer, r chan core.Result) {
+	// own our cancel so the quitChan goroutine below always terminates when identify returns,
+	// rather than outliving it until the caller's (possibly batch-scoped) ctx is done.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	quit := quitChan(ctx)
 	buf.SetQuit(quit)
 	bprog, eprog := make(chan int), make(chan int)
 	gate := make(chan struct{})
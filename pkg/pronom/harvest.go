@@ -0,0 +1,166 @@
+// Copyright 2014 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pronom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/config"
+)
+
+const cacheFile = "harvest-cache.json"
+
+// cacheEntry records the conditional GET state for a single harvested PRONOM report.
+type cacheEntry struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// loadCache reads the ETag/Last-Modified cache from dir, returning an empty cache if none exists yet.
+func loadCache(dir string) (map[string]cacheEntry, error) {
+	cache := make(map[string]cacheEntry)
+	f, err := os.Open(filepath.Join(dir, cacheFile))
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// saveCache writes the ETag/Last-Modified cache to dir.
+func saveCache(dir string, cache map[string]cacheEntry) error {
+	f, err := os.Create(filepath.Join(dir, cacheFile))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(cache)
+}
+
+// SaveReport fetches a single PRONOM report by puid, storing it in dir. If config.HarvestRefresh()
+// is set, the fetch is conditional on config.HarvestCache()'s recorded ETag/Last-Modified for the
+// puid, and a 304 Not Modified response reuses the on-disk copy rather than re-downloading it. If
+// HarvestRefresh() is not set, every report is fetched unconditionally, as before the cache was
+// introduced.
+func SaveReport(puid, url, dir string) error {
+	fp := filepath.Join(dir, strings.Replace(puid, "/", "-", 1)+".xml")
+	refresh := config.HarvestRefresh()
+	var cache map[string]cacheEntry
+	if refresh {
+		var err error
+		cache, err = loadCache(config.HarvestCache())
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest("GET", url+"reports/"+puid+".xml", nil)
+	if err != nil {
+		return err
+	}
+	if refresh {
+		if entry, ok := cache[puid]; ok {
+			if _, serr := os.Stat(fp); serr == nil {
+				if entry.ETag != "" {
+					req.Header.Set("If-None-Match", entry.ETag)
+				}
+				if entry.LastModified != "" {
+					req.Header.Set("If-Modified-Since", entry.LastModified)
+				}
+			}
+		}
+	}
+	_, timeout, transport := config.HarvestOptions()
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil // reuse the on-disk report
+	case http.StatusOK:
+		out, err := os.Create(fp)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if _, err := io.Copy(out, resp.Body); err != nil {
+			return err
+		}
+		if !refresh {
+			return nil
+		}
+		cache[puid] = cacheEntry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		return saveCache(config.HarvestCache(), cache)
+	default:
+		return fmt.Errorf("pronom: unexpected status %s fetching report for %s", resp.Status, puid)
+	}
+}
+
+// SaveReports fetches the PRONOM reports for the given puids, storing them in dir. See SaveReport
+// for how config.HarvestRefresh() changes fetch behaviour.
+func SaveReports(puids []string, url, dir string) []error {
+	var errs []error
+	for _, puid := range puids {
+		if err := SaveReport(puid, url, dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// SaveSignatureFile fetches the DROID or container signature file at url, saves it as base within
+// dir, and records its provenance (digest, source URL, PRONOM release version, harvest time)
+// alongside it in dir's signature manifest via config.SaveManifestEntry, so that
+// DroidVerified/ContainerVerified (which look for the manifest next to the file they're
+// validating) can find it.
+func SaveSignatureFile(base, url, dir, version string) error {
+	_, timeout, transport := config.HarvestOptions()
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pronom: unexpected status %s fetching signature file %s", resp.Status, base)
+	}
+	out, err := os.Create(filepath.Join(dir, base))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return err
+	}
+	return config.SaveManifestEntry(dir, base, url, version, time.Now())
+}
@@ -0,0 +1,133 @@
+// Copyright 2014 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pronom
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/config"
+)
+
+func TestSaveReportRefreshModes(t *testing.T) {
+	const puid = "fmt/1"
+	var hits, conditional int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("<report/>"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "pronom-harvest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("refresh disabled fetches unconditionally every time", func(t *testing.T) {
+		hits, conditional = 0, 0
+		if err := SaveReport(puid, srv.URL+"/", dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveReport(puid, srv.URL+"/", dir); err != nil {
+			t.Fatal(err)
+		}
+		if hits != 2 || conditional != 0 {
+			t.Errorf("got hits=%d conditional=%d, want hits=2 conditional=0", hits, conditional)
+		}
+	})
+
+	t.Run("refresh enabled reuses unchanged reports via conditional GET", func(t *testing.T) {
+		config.SetHarvestCache(dir)
+		config.SetHarvestRefresh()
+		t.Cleanup(func() {
+			config.SetHarvestCache("")
+			config.ClearHarvestRefresh()
+		})
+		hits, conditional = 0, 0
+		if err := SaveReport(puid, srv.URL+"/", dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveReport(puid, srv.URL+"/", dir); err != nil {
+			t.Fatal(err)
+		}
+		if hits != 2 || conditional != 1 {
+			t.Errorf("got hits=%d conditional=%d, want hits=2 conditional=1", hits, conditional)
+		}
+	})
+}
+
+// TestSaveSignatureFile checks that SaveSignatureFile downloads the file to dir and records its
+// provenance in dir's manifest, so that config.DroidVerified/ContainerVerified - which look for
+// the manifest alongside the file they're validating - can find it.
+func TestSaveSignatureFile(t *testing.T) {
+	const base = "DROID_SignatureFile_V999.xml"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<droid/>"))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "pronom-signature-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := SaveSignatureFile(base, srv.URL+"/"+base, dir, "999"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(dir, base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "<droid/>" {
+		t.Errorf("got %q, want <droid/>", got)
+	}
+	manifest, err := ioutil.ReadFile(filepath.Join(dir, "signatures.manifest.json"))
+	if err != nil {
+		t.Fatalf("expected a manifest file alongside the signature file, got %v", err)
+	}
+	if !strings.Contains(string(manifest), base) {
+		t.Errorf("manifest %s doesn't mention %s", manifest, base)
+	}
+}
+
+func TestSaveSignatureFileBadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "pronom-signature-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := SaveSignatureFile("DROID_SignatureFile_V999.xml", srv.URL, dir, "999"); err == nil {
+		t.Error("expected an error for a non-200 response")
+	}
+}